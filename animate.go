@@ -0,0 +1,88 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+import (
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Stats reports on the cost of the most recent Flush, so animation
+// authors can tell whether their draw function fits the frame budget.
+type Stats struct {
+	RenderTime   time.Duration // time spent diffing and writing in Flush
+	BytesWritten int           // bytes written to the terminal
+	CellsChanged int           // cells that differed from the front buffer
+}
+
+// Stats returns stats for the most recently completed Flush.
+func (c *Canvas) Stats() Stats {
+	return c.stats
+}
+
+func (c *Canvas) StatsSafe() Stats {
+	c.mutex.Lock()
+	s := c.stats
+	c.mutex.Unlock()
+	return s
+}
+
+// Animate owns the timing loop for an animation: it calls draw at a
+// stable cadence of fps frames per second, passing the frame number
+// and the real time elapsed since the previous call, and flushes the
+// canvas after each call. It returns when draw returns false or
+// Ctrl+C is pressed.
+//
+// Cadence is held by a time.Ticker, which self-corrects drift rather
+// than accumulating the error a flat time.Sleep(1000/fps) would; if
+// draw and Flush together overrun a frame's budget, any ticks that
+// queued up in the meantime are dropped so the next call to draw
+// still gets the full budget instead of being called back-to-back to
+// "catch up".
+//
+// fps is clamped to a minimum of 1, since time.NewTicker panics given
+// a non-positive duration.
+func (c *Canvas) Animate(fps int, draw func(frame int, dt time.Duration) bool) {
+	if fps < 1 {
+		fps = 1
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt)
+	defer signal.Stop(sigint)
+
+	last := time.Now()
+	for frame := 0; ; frame++ {
+		select {
+		case <-sigint:
+			return
+		case tick := <-ticker.C:
+			dt := tick.Sub(last)
+			last = tick
+			if !draw(frame, dt) {
+				return
+			}
+			c.FlushSafe()
+			drainTicks(ticker)
+		}
+	}
+}
+
+// drainTicks discards any ticks that arrived while draw/Flush were
+// running, so a slow frame is followed by one more full-budget frame
+// rather than a burst of back-to-back catch-up frames.
+func drainTicks(ticker *time.Ticker) {
+	for {
+		select {
+		case <-ticker.C:
+		default:
+			return
+		}
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"log"
 	"math/rand"
 	"os"
-	"os/exec"
 	"strconv"
 	"sync"
 	"time"
@@ -33,6 +32,16 @@ var (
 // to use this in a multi-threaded fashion, then use the non
 // thread-safe functions as they offer better performance which is
 // critical in animation and gaming applications.
+//
+// Internally, Canvas keeps two grids of cells: a back buffer that
+// Set/Write/WriteAt mutate, and a front buffer that mirrors what is
+// currently on the terminal. Flush() walks the two grids, emits the
+// minimum sequence of cursor moves and SGR changes needed to bring the
+// terminal in sync with the back buffer (with any sprite layers
+// composited over it), and then copies that frame into the front
+// buffer. This avoids erasing and redrawing
+// every cell on every frame, and gives correct results even when
+// multiple goroutines paint into overlapping regions between flushes.
 type Canvas struct {
 	// Width is the width of the canvas
 	Width int
@@ -51,16 +60,53 @@ type Canvas struct {
 	// between), when there is an abrupt termination.
 	CursorOnEnd bool
 
-	buf   bytes.Buffer
+	// ColorMode controls how Color values are rendered. The default,
+	// ColorModeAuto, detects the terminal's capability (truecolor,
+	// 256-color or basic 16-color) on first use, and renders nothing
+	// at all when stdout isn't a terminal.
+	ColorMode ColorMode
+
 	mutex sync.Mutex
 
-	x     int
+	autoColorMode ColorMode // cached result of detectColorMode(), valid once != ColorModeAuto
+
+	front [][]cell // last frame written to the terminal
+	back  [][]cell // pending frame, mutated by Set/Write/WriteAt
+
+	x     int // logical write cursor used by Write/WriteAt
 	y     int
 	fg    Color
 	bg    Color
 	style int
+
+	termX     int // cursor position last emitted to the terminal
+	termY     int
+	termFg    Color
+	termBg    Color
+	termStyle int
+
+	layers      map[int]*layer // sprite layers composited over the back buffer at Flush time
+	nextLayerID int
+
+	restoreConsole func() // undoes enableVT(); no-op on non-Windows
+
+	stats Stats // populated by the most recent Flush
 }
 
+// cell is a single terminal cell: a rune plus the attributes it was
+// painted with.
+type cell struct {
+	r     rune
+	fg    Color
+	bg    Color
+	style int
+}
+
+// invalidCell never equals a real cell (style 0 is not a valid style
+// bitmask since StyleNoChange is 1<<0), so a grid filled with it forces
+// every cell to be considered "changed" on the next Flush/Redraw.
+var invalidCell = cell{r: 0, style: 0}
+
 // Color defines a color either expressed as an RGBA value or as one of
 // the 256 colors on a terminal that supports 256 colors.
 type Color struct {
@@ -75,8 +121,15 @@ type Color struct {
 	Term256 uint8
 
 	isDefault bool
+	invalid   bool // set only by invalidColor, below
 }
 
+// invalidColor never equals a real Color, including the zero value
+// (which is ColorBlack): callers that need to force a re-emit of
+// whatever color is actually on the terminal, such as setSGR after a
+// style reset, compare against this instead of Color{}.
+var invalidColor = Color{invalid: true}
+
 var (
 	ColorDefault      = Color{isDefault: true}
 	ColorBlack        = Color{Term256: 0}
@@ -121,24 +174,53 @@ func NewCanvas(width, height int, background Color) *Canvas {
 		Height:     height,
 		Background: background,
 	}
-	c.SetBackground(background)
-	c.SetStyle(StyleNormal)
-	for i := 0; i < c.Height; i++ {
-		for i := 0; i < c.Width; i++ {
-			c.buf.WriteString(" ")
-		}
-		if i < c.Height-1 {
-			c.SetBackground(ColorDefault) //to ensure that [width,terminalWidth) is not colored in background color
-			c.buf.WriteString("\n")
-			c.SetBackground(background)
+	// No-op except on Windows, where it switches the console into
+	// virtual-terminal mode so the ANSI escapes this package writes
+	// are interpreted instead of printed literally.
+	c.restoreConsole, _ = enableVT()
+	c.fg, c.bg, c.style = ColorDefault, background, StyleNormal
+	c.front = newGrid(width, height, invalidCell) //differs from back everywhere so the first Flush draws every cell
+	c.back = newGrid(width, height, cell{r: ' ', fg: ColorDefault, bg: background, style: StyleNormal})
+
+	// Reserve the canvas' footprint on the terminal and return the
+	// cursor to column 0 of its last row, so that the first Flush()'s
+	// relative cursor moves start from a known position.
+	var reserve bytes.Buffer
+	for i := 0; i < height; i++ {
+		reserve.WriteString(blank(width))
+		if i < height-1 {
+			reserve.WriteString("\n")
 		}
 	}
-	c.x, c.y = width, height-1 //this is the only time c.x is out of bounds, legally.
+	reserve.WriteString("\r")
+	fmt.Fprint(stdout, reserve.String())
+	c.termX, c.termY = 0, height-1
+	c.termFg, c.termBg, c.termStyle = ColorDefault, ColorDefault, StyleNormal
 	c.Move(0, 0)
 	c.Flush()
 	return c
 }
 
+func newGrid(width, height int, fill cell) [][]cell {
+	grid := make([][]cell, height)
+	for y := range grid {
+		row := make([]cell, width)
+		for x := range row {
+			row[x] = fill
+		}
+		grid[y] = row
+	}
+	return grid
+}
+
+func blank(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	return string(b)
+}
+
 func (c *Canvas) Clear() {
 	c.Move(0, 0)
 	for i := 0; i < c.Height; i++ {
@@ -158,11 +240,26 @@ func (c *Canvas) ClearSafe() {
 	c.mutex.Unlock()
 }
 
-func (c *Canvas) SetBackground(clr Color) {
-	if c.bg == clr {
-		return
+// Redraw invalidates every cell of the front buffer, forcing the next
+// Flush() to repaint the entire canvas. Call this after a terminal
+// resize or after anything else (e.g. another program) may have
+// clobbered the screen region the canvas occupies.
+func (c *Canvas) Redraw() {
+	for y := range c.front {
+		row := c.front[y]
+		for x := range row {
+			row[x] = invalidCell
+		}
 	}
-	c.setBgFg(clr, true)
+}
+
+func (c *Canvas) RedrawSafe() {
+	c.mutex.Lock()
+	c.Redraw()
+	c.mutex.Unlock()
+}
+
+func (c *Canvas) SetBackground(clr Color) {
 	c.bg = clr
 }
 
@@ -174,10 +271,6 @@ func (c *Canvas) SetBackgroundSafe(clr Color) {
 }
 
 func (c *Canvas) SetForeground(clr Color) {
-	if c.fg == clr {
-		return
-	}
-	c.setBgFg(clr, false)
 	c.fg = clr
 }
 
@@ -187,58 +280,7 @@ func (c *Canvas) SetForegroundSafe(clr Color) {
 	c.mutex.Unlock()
 }
 
-func (c *Canvas) setBgFg(clr Color, bg bool) {
-	var op string
-	if bg {
-		op = "4"
-	} else {
-		op = "3"
-	}
-	if clr.isDefault {
-		c.buf.WriteString(escape + "[")
-		c.buf.WriteString(op)
-		c.buf.WriteString("9m")
-		return
-	}
-	var x256Clr int
-	if clr.RGBA != nil {
-		x256Clr = Colors.Index(clr.RGBA)
-	} else {
-		x256Clr = int(clr.Term256)
-	}
-	c.buf.WriteString(escape + "[")
-	c.buf.WriteString(op)
-	c.buf.WriteString("8;5;")
-	c.buf.WriteString(strconv.Itoa(x256Clr))
-	c.buf.WriteString("m")
-}
-
 func (c *Canvas) SetStyle(style int) {
-
-	if c.style == style {
-		return
-	}
-	if style&StyleNormal != 0 {
-		c.buf.WriteString(escape + "[0m")
-	}
-	if style&StyleBold != 0 {
-		c.buf.WriteString(escape + "[1m")
-	}
-	if style&StyleDim != 0 {
-		c.buf.WriteString(escape + "[2m")
-	}
-	if style&StyleUnderlined != 0 {
-		c.buf.WriteString(escape + "[4m")
-	}
-	if style&StyleBlink != 0 {
-		c.buf.WriteString(escape + "[5m")
-	}
-	if style&StyleInverted != 0 {
-		c.buf.WriteString(escape + "[7m")
-	}
-	if style&StyleHidden != 0 {
-		c.buf.WriteString(escape + "[8m")
-	}
 	c.style = style
 }
 
@@ -260,28 +302,14 @@ func (c *Canvas) SetSafe(x, y int, char rune) {
 	c.mutex.Unlock()
 }
 
+// Move positions the logical write cursor used by Write/WriteAt. It
+// does not touch the terminal; the actual cursor is only moved when
+// Flush() emits changed cells.
 func (c *Canvas) Move(x, y int) error {
-	if c.x == x && c.y == y {
-		return nil
-	}
-
 	if x > c.Width-1 || x < 0 || y > c.Height-1 || y < 0 {
 		c.errorF("out of bounds: (%d,%d)", x, y)
 		return ErrorOutOfBounds
 	}
-	move := func(curr, given int, forwardOp string, backwardOp string) {
-		write := func(num int, op string) {
-			c.buf.WriteString(escape + "[" + strconv.Itoa(num) + op)
-		}
-		if curr < given {
-			write(given-curr, forwardOp)
-		} else if curr > given {
-			write(curr-given, backwardOp)
-		}
-	}
-
-	move(c.x, x, "C", "D")
-	move(c.y, y, "B", "A")
 	c.x, c.y = x, y
 	return nil
 }
@@ -293,14 +321,18 @@ func (c *Canvas) MoveSafe(x, y int) error {
 	return err
 }
 
+// Write paints text into the back buffer starting at the logical
+// cursor, using the current foreground, background and style.
 func (c *Canvas) Write(text string) {
 	if r, l := c.Width-c.x, utf8.RuneCountInString(text); l > r {
 		orig := text
 		text = text[0:r] //TODO: fix this so that runes are truncated not in the middle of their bytes
 		c.errorF("string truncated: %s", orig)
 	}
-	c.buf.WriteString(text)
-	c.x += utf8.RuneCountInString(text)
+	for _, r := range text {
+		c.back[c.y][c.x] = cell{r: r, fg: c.fg, bg: c.bg, style: c.style}
+		c.x++
+	}
 	if c.x >= c.Width {
 		c.Move(c.Width-1, c.y)
 	}
@@ -328,12 +360,87 @@ func (c *Canvas) WriteAtSafe(x, y int, foreground, background Color, style int,
 	c.mutex.Unlock()
 }
 
+// Flush composites any layers over the back buffer, then brings the
+// terminal in sync with the result. In ColorModeNone (stdout isn't a
+// terminal) there's no cursor to track, so it just prints the frame as
+// plain text, one line per row, with no escapes at all; otherwise it
+// diffs against the front buffer and writes the minimum sequence of
+// cursor moves, SGR changes and runes needed. Either way, the
+// composited frame is then copied into the front buffer. The back
+// buffer itself is left untouched by layers, so it always reflects
+// only what Set/Write/WriteAt painted.
 func (c *Canvas) Flush() {
+	start := time.Now()
+	frame := c.composeFrame()
+	var out bytes.Buffer
+	var changed int
+	if c.effectiveColorMode() == ColorModeNone {
+		changed = c.writePlain(&out, frame)
+	} else {
+		changed = c.writeDiff(&out, frame)
+	}
+	fmt.Fprint(stdout, out.String())
+	c.stats = Stats{RenderTime: time.Since(start), BytesWritten: out.Len(), CellsChanged: changed}
+}
+
+// writeDiff is Flush's terminal path: it diffs frame against the front
+// buffer and writes the minimum sequence of cursor moves, SGR changes
+// and runes needed to bring the terminal in sync, copying each changed
+// cell into front as it goes. It returns the number of cells changed.
+func (c *Canvas) writeDiff(out *bytes.Buffer, frame [][]cell) int {
+	changed := 0
+	for y := 0; y < c.Height; y++ {
+		row, front := frame[y], c.front[y]
+		for x := 0; x < c.Width; x++ {
+			next := row[x]
+			if next == front[x] {
+				continue
+			}
+			c.moveTerm(out, x, y)
+			c.setSGR(out, next.fg, next.bg, next.style)
+			out.WriteRune(next.r)
+			if x == c.Width-1 {
+				// After the canvas' last column the cursor is either
+				// one column further right (canvas narrower than the
+				// terminal) or left pending at the terminal's last
+				// column (autowrap). A carriage return puts it at a
+				// known column in both cases.
+				out.WriteByte('\r')
+				c.termX = 0
+			} else {
+				c.termX = x + 1
+			}
+			front[x] = next
+			changed++
+		}
+	}
 	if c.CursorOnEnd {
-		c.Move(c.Width-1, c.Height-1)
+		c.moveTerm(out, c.Width-1, c.Height-1)
+	}
+	return changed
+}
+
+// writePlain is Flush's ColorModeNone path: stdout isn't a terminal,
+// so there's nothing a cursor move or SGR escape would mean to it. It
+// writes frame as plain text, one newline-terminated line per row, and
+// copies frame into front so a later Flush (e.g. after ColorMode is
+// changed back) has an accurate front buffer to diff against. It
+// returns the number of cells that differed from front.
+func (c *Canvas) writePlain(out *bytes.Buffer, frame [][]cell) int {
+	changed := 0
+	for y := 0; y < c.Height; y++ {
+		row, front := frame[y], c.front[y]
+		for x := 0; x < c.Width; x++ {
+			next := row[x]
+			if next != front[x] {
+				front[x] = next
+				changed++
+			}
+			out.WriteRune(next.r)
+		}
+		out.WriteByte('\n')
 	}
-	fmt.Printf(c.buf.String())
-	c.buf.Reset()
+	return changed
 }
 
 func (c *Canvas) FlushSafe() {
@@ -342,6 +449,89 @@ func (c *Canvas) FlushSafe() {
 	c.mutex.Unlock()
 }
 
+// Close restores any console mode NewCanvas changed. It's a no-op on
+// platforms other than Windows; callers that want the canvas to work
+// there too should defer it alongside ShowCursor/EnableEcho.
+func (c *Canvas) Close() error {
+	if c.restoreConsole != nil {
+		c.restoreConsole()
+	}
+	return nil
+}
+
+// moveTerm emits the relative cursor movement escapes needed to bring
+// the real terminal cursor from (c.termX,c.termY) to (x,y) and writes
+// them to out.
+func (c *Canvas) moveTerm(out *bytes.Buffer, x, y int) {
+	if c.termX == x && c.termY == y {
+		return
+	}
+	move := func(curr, given int, forwardOp string, backwardOp string) {
+		write := func(num int, op string) {
+			out.WriteString(escape + "[" + strconv.Itoa(num) + op)
+		}
+		if curr < given {
+			write(given-curr, forwardOp)
+		} else if curr > given {
+			write(curr-given, backwardOp)
+		}
+	}
+	move(c.termX, x, "C", "D")
+	move(c.termY, y, "B", "A")
+	c.termX, c.termY = x, y
+}
+
+// setSGR emits the escapes needed to bring the terminal's current
+// foreground, background and style in line with fg, bg and style, and
+// writes them to out.
+func (c *Canvas) setSGR(out *bytes.Buffer, fg, bg Color, style int) {
+	mode := c.effectiveColorMode()
+	if mode == ColorModeNone {
+		return
+	}
+	if c.termStyle != style {
+		writeStyle(out, style)
+		c.termStyle = style
+		// A style reset (SGR 0) also clears any previously set
+		// colors, so force them to be re-emitted below.
+		if style&StyleNormal != 0 {
+			c.termFg, c.termBg = invalidColor, invalidColor
+		}
+	}
+	if c.termBg != bg {
+		writeBgFg(out, bg, true, mode)
+		c.termBg = bg
+	}
+	if c.termFg != fg {
+		writeBgFg(out, fg, false, mode)
+		c.termFg = fg
+	}
+}
+
+func writeStyle(out *bytes.Buffer, style int) {
+	if style&StyleNormal != 0 {
+		out.WriteString(escape + "[0m")
+	}
+	if style&StyleBold != 0 {
+		out.WriteString(escape + "[1m")
+	}
+	if style&StyleDim != 0 {
+		out.WriteString(escape + "[2m")
+	}
+	if style&StyleUnderlined != 0 {
+		out.WriteString(escape + "[4m")
+	}
+	if style&StyleBlink != 0 {
+		out.WriteString(escape + "[5m")
+	}
+	if style&StyleInverted != 0 {
+		out.WriteString(escape + "[7m")
+	}
+	if style&StyleHidden != 0 {
+		out.WriteString(escape + "[8m")
+	}
+}
+
 func (c *Canvas) errorF(msg string, args ...interface{}) {
 	if c.Logger != nil {
 		c.Logger.Printf("[error] "+msg, args...)
@@ -355,25 +545,11 @@ func (c *Canvas) debugF(msg string, args ...interface{}) {
 }
 
 func HideCursor() {
-	fmt.Printf(escape + "[?25l")
+	fmt.Fprint(stdout, escape+"[?25l")
 }
 
 func ShowCursor() {
-	fmt.Printf(escape + "[?25h")
-}
-
-func DisableEcho() {
-	toggleEcho("-")
-}
-
-func EnableEcho() {
-	toggleEcho("")
-}
-
-func toggleEcho(prefix string) {
-	cmd := exec.Command("stty", prefix+"echo")
-	cmd.Stdin = os.Stdin
-	cmd.Run()
+	fmt.Fprint(stdout, escape+"[?25h")
 }
 
 func main() {
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ColorMode controls which SGR escapes Canvas emits for a Color.
+type ColorMode int
+
+const (
+	// ColorModeAuto detects the terminal's capability on first use:
+	// truecolor via $COLORTERM, 256-color via $TERM, 16-color
+	// otherwise, or no color at all when stdout isn't a terminal or
+	// $TERM is empty or "dumb".
+	ColorModeAuto ColorMode = iota
+
+	// ColorModeNone emits no escapes at all, not even cursor moves:
+	// Flush falls back to printing each frame as plain text, one line
+	// per row. This is the auto-detected mode when stdout is
+	// redirected to a file or pipe.
+	ColorModeNone
+
+	// ColorMode16 emits the eight basic and eight bright ANSI colors.
+	ColorMode16
+
+	// ColorMode256 emits the xterm 256-color palette (the long
+	// standing default of this package).
+	ColorMode256
+
+	// ColorModeTrueColor emits 24-bit RGB escapes so RGBA Colors
+	// render exactly instead of being snapped to the nearest
+	// 256-color index.
+	ColorModeTrueColor
+)
+
+// effectiveColorMode resolves c.ColorMode, running terminal capability
+// detection once (and caching the result) if it's ColorModeAuto.
+func (c *Canvas) effectiveColorMode() ColorMode {
+	if c.ColorMode != ColorModeAuto {
+		return c.ColorMode
+	}
+	if c.autoColorMode == ColorModeAuto {
+		c.autoColorMode = detectColorMode()
+	}
+	return c.autoColorMode
+}
+
+// detectColorMode inspects whether stdout is a terminal and, if so,
+// $COLORTERM and $TERM, the same signals mattn/go-isatty and
+// consumers like go-colorable base their decisions on.
+func detectColorMode() ColorMode {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ColorModeNone
+	}
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColorModeTrueColor
+	}
+	termEnv := os.Getenv("TERM")
+	switch {
+	case termEnv == "" || termEnv == "dumb":
+		return ColorModeNone
+	case strings.Contains(termEnv, "256color"):
+		return ColorMode256
+	default:
+		return ColorMode16
+	}
+}
+
+func writeBgFg(out *bytes.Buffer, clr Color, bg bool, mode ColorMode) {
+	var op string
+	if bg {
+		op = "4"
+	} else {
+		op = "3"
+	}
+	if clr.isDefault {
+		out.WriteString(escape + "[")
+		out.WriteString(op)
+		out.WriteString("9m")
+		return
+	}
+	if mode == ColorModeTrueColor && clr.RGBA != nil {
+		r, g, b := rgb(clr.RGBA)
+		out.WriteString(escape + "[")
+		out.WriteString(op)
+		out.WriteString("8;2;")
+		out.WriteString(strconv.Itoa(r))
+		out.WriteString(";")
+		out.WriteString(strconv.Itoa(g))
+		out.WriteString(";")
+		out.WriteString(strconv.Itoa(b))
+		out.WriteString("m")
+		return
+	}
+	if mode == ColorMode16 {
+		out.WriteString(escape + "[")
+		out.WriteString(ansi16Code(clr, bg))
+		out.WriteString("m")
+		return
+	}
+	var x256Clr int
+	if clr.RGBA != nil {
+		x256Clr = Colors.Index(clr.RGBA)
+	} else {
+		x256Clr = int(clr.Term256)
+	}
+	out.WriteString(escape + "[")
+	out.WriteString(op)
+	out.WriteString("8;5;")
+	out.WriteString(strconv.Itoa(x256Clr))
+	out.WriteString("m")
+}
+
+// rgb extracts 8-bit per-channel R,G,B from a color.Color, whose
+// native RGBA() returns each channel alpha-premultiplied in [0,65535].
+func rgb(clr color.Color) (int, int, int) {
+	r, g, b, _ := clr.RGBA()
+	return int(r >> 8), int(g >> 8), int(b >> 8)
+}
+
+// ansi16Code approximates clr down to one of the 8 basic or 8 bright
+// ANSI colors and returns its SGR parameter (30-37/90-97 for
+// foreground, 40-47/100-107 for background).
+func ansi16Code(clr Color, bg bool) string {
+	idx := clr.Term256
+	if clr.RGBA != nil {
+		idx = uint8(Colors.Index(clr.RGBA))
+	}
+	code := ansi16Index(idx)
+	base := 30
+	if code >= 8 {
+		base = 90
+		code -= 8
+	}
+	if bg {
+		base += 10
+	}
+	return strconv.Itoa(base + code)
+}
+
+// ansi16Index maps an xterm 256-color index down to 0-15. Indices
+// 0-15 already are the 16-color palette; the grayscale ramp maps to
+// dark gray or white. In the 6x6x6 color cube, each of R, G and B
+// above the cube's midpoint sets that color's bit and makes the
+// result bright; a cube color with no channel above the midpoint
+// maps to black.
+func ansi16Index(term256 uint8) int {
+	if term256 < 16 {
+		return int(term256)
+	}
+	if term256 >= 232 { // grayscale ramp
+		if term256 < 244 {
+			return 8 // dark gray
+		}
+		return 15 // white
+	}
+	n := int(term256) - 16
+	r, g, b := (n/36)%6, (n/6)%6, n%6
+	bright := 0
+	if r > 2 || g > 2 || b > 2 {
+		bright = 8
+	}
+	idx := 0
+	if r > 2 {
+		idx |= 1
+	}
+	if g > 2 {
+		idx |= 2
+	}
+	if b > 2 {
+		idx |= 4
+	}
+	return idx | bright
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build !windows
+
+package canvas
+
+// enableVT is a no-op on platforms other than Windows, whose
+// terminals already interpret ANSI escapes natively.
+func enableVT() (restore func(), err error) {
+	return func() {}, nil
+}
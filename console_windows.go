@@ -0,0 +1,50 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build windows
+
+package canvas
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// enableVT puts the console into virtual-terminal mode so the ANSI
+// escapes this package writes are interpreted by the console host
+// instead of printed literally, and returns a func that restores the
+// console's prior modes. If the console host is too old to support
+// virtual-terminal processing (pre Windows 10 1511), stdout is instead
+// swapped for a writer that translates those same escapes into Win32
+// console calls, the approach github.com/mattn/go-colorable takes.
+func enableVT() (restore func(), err error) {
+	out := windows.Stdout
+	in := windows.Stdin
+
+	var outMode, inMode uint32
+	if err := windows.GetConsoleMode(out, &outMode); err != nil {
+		return func() {}, err
+	}
+	if err := windows.GetConsoleMode(in, &inMode); err != nil {
+		return func() {}, err
+	}
+
+	restoreModes := func() {
+		windows.SetConsoleMode(out, outMode)
+		windows.SetConsoleMode(in, inMode)
+	}
+
+	if setErr := windows.SetConsoleMode(out, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); setErr != nil {
+		// No virtual-terminal support: fall back to translating our
+		// own escapes into direct console API calls.
+		stdout = newConsoleWriter(out)
+		return restoreModes, nil
+	}
+
+	newIn := inMode | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	newIn &^= windows.ENABLE_ECHO_INPUT | windows.ENABLE_LINE_INPUT
+	windows.SetConsoleMode(in, newIn)
+
+	return restoreModes, nil
+}
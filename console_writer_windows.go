@@ -0,0 +1,271 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build windows
+
+package canvas
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// Console text attribute bits (Win32 wincon.h). golang.org/x/sys/windows
+// doesn't export these or SetConsoleTextAttribute itself, so both are
+// defined here the way github.com/mattn/go-colorable does.
+const (
+	foregroundBlue      = 0x0001
+	foregroundGreen     = 0x0002
+	foregroundRed       = 0x0004
+	foregroundIntensity = 0x0008
+	backgroundBlue      = 0x0010
+	backgroundGreen     = 0x0020
+	backgroundRed       = 0x0040
+	backgroundIntensity = 0x0080
+)
+
+var procSetConsoleTextAttribute = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetConsoleTextAttribute")
+
+func setConsoleTextAttribute(handle windows.Handle, attr uint16) {
+	procSetConsoleTextAttribute.Call(uintptr(handle), uintptr(attr))
+}
+
+// consoleWriter translates the relative cursor moves and SGR
+// color/style changes this package emits into direct Win32 console
+// API calls, for the consoles too old to understand
+// ENABLE_VIRTUAL_TERMINAL_PROCESSING. Other CSI sequences, such as
+// the "\e[?25l" private modes, are consumed and dropped. It
+// implements io.Writer.
+type consoleWriter struct {
+	handle windows.Handle
+	attr   uint16 // last attribute word sent to SetConsoleTextAttribute
+}
+
+func newConsoleWriter(handle windows.Handle) *consoleWriter {
+	w := &consoleWriter{handle: handle}
+	var info windows.ConsoleScreenBufferInfo
+	if windows.GetConsoleScreenBufferInfo(handle, &info) == nil {
+		w.attr = info.Attributes
+	}
+	return w
+}
+
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		if p[0] == 0x1b && len(p) > 1 && p[1] == '[' {
+			n := w.applyEscape(p)
+			if n == 0 {
+				break // unterminated; stop rather than loop forever
+			}
+			p = p[n:]
+			continue
+		}
+		// Find the run of plain text up to the next escape, if any,
+		// and write it in one shot.
+		i := 1
+		for i < len(p) && p[i] != 0x1b {
+			i++
+		}
+		w.writeText(p[:i])
+		p = p[i:]
+	}
+	return total, nil
+}
+
+// applyEscape consumes one "\e[...final" sequence from the front of p
+// and returns its length, or 0 if p ends before the sequence's final
+// byte. Sequences other than cursor moves and SGR are consumed without
+// effect.
+func (w *consoleWriter) applyEscape(p []byte) int {
+	i := 2
+	for i < len(p) && (p[i] == ';' || p[i] == '?' || (p[i] >= '0' && p[i] <= '9')) {
+		i++
+	}
+	if i >= len(p) {
+		return 0
+	}
+	final := p[i]
+	params := string(p[2:i])
+	switch final {
+	case 'A', 'B', 'C', 'D':
+		w.moveCursor(final, atoiDefault(params, 1))
+	case 'm':
+		w.applySGR(params)
+	}
+	return i + 1
+}
+
+func (w *consoleWriter) moveCursor(dir byte, n int) {
+	var info windows.ConsoleScreenBufferInfo
+	if windows.GetConsoleScreenBufferInfo(w.handle, &info) != nil {
+		return
+	}
+	pos := info.CursorPosition
+	switch dir {
+	case 'A':
+		pos.Y -= int16(n)
+	case 'B':
+		pos.Y += int16(n)
+	case 'C':
+		pos.X += int16(n)
+	case 'D':
+		pos.X -= int16(n)
+	}
+	windows.SetConsoleCursorPosition(w.handle, pos)
+}
+
+// applySGR folds one or more ';'-separated SGR parameters into the
+// console's single attribute word and applies it.
+func (w *consoleWriter) applySGR(params string) {
+	if params == "" {
+		params = "0"
+	}
+	parts := strings.Split(params, ";")
+	for i := 0; i < len(parts); i++ {
+		code := atoiDefault(parts[i], 0)
+		switch {
+		case code == 0:
+			w.attr = defaultAttr
+		case code == 1:
+			w.attr |= foregroundIntensity
+		case code == 7:
+			w.attr = swapFgBg(w.attr)
+		case code >= 30 && code <= 37:
+			w.attr = setFg(w.attr, ansiColorAttr(code-30))
+		case code >= 90 && code <= 97:
+			w.attr = setFg(w.attr, ansiColorAttr(code-90)|foregroundIntensity)
+		case code >= 40 && code <= 47:
+			w.attr = setBg(w.attr, ansiColorAttr(code-40))
+		case code >= 100 && code <= 107:
+			w.attr = setBg(w.attr, ansiColorAttr(code-100)|backgroundIntensity)
+		case code == 38 || code == 48:
+			// 256-color (38/48;5;N) or truecolor (38/48;2;R;G;B):
+			// approximate down to the nearest basic 8 colors, which
+			// is all a legacy console buffer attribute can express.
+			i = w.applyExtendedColor(parts, i, code == 38)
+		}
+	}
+	setConsoleTextAttribute(w.handle, w.attr)
+}
+
+// applyExtendedColor consumes the "5;N" or "2;R;G;B" that follows a
+// 38/48 SGR code starting at parts[i+1], returning the new index.
+func (w *consoleWriter) applyExtendedColor(parts []string, i int, fg bool) int {
+	if i+1 >= len(parts) {
+		return i
+	}
+	switch parts[i+1] {
+	case "5":
+		if i+2 >= len(parts) {
+			return i + 1
+		}
+		n := atoiDefault(parts[i+2], 0)
+		c := ansiColorAttr(nearestBasicColor(n))
+		if fg {
+			w.attr = setFg(w.attr, c)
+		} else {
+			w.attr = setBg(w.attr, c)
+		}
+		return i + 2
+	case "2":
+		if i+4 >= len(parts) {
+			return i + 1
+		}
+		r := atoiDefault(parts[i+2], 0)
+		g := atoiDefault(parts[i+3], 0)
+		b := atoiDefault(parts[i+4], 0)
+		c := ansiColorAttr(nearestBasicColorRGB(r, g, b))
+		if fg {
+			w.attr = setFg(w.attr, c)
+		} else {
+			w.attr = setBg(w.attr, c)
+		}
+		return i + 4
+	}
+	return i
+}
+
+func (w *consoleWriter) writeText(p []byte) {
+	setConsoleTextAttribute(w.handle, w.attr)
+	utf16, err := windows.UTF16FromString(string(p))
+	if err != nil {
+		return
+	}
+	var written uint32
+	windows.WriteConsole(w.handle, &utf16[0], uint32(len(utf16)-1), &written, nil)
+}
+
+const defaultAttr = foregroundRed | foregroundGreen | foregroundBlue
+
+// ansiColorAttr maps an ANSI 0-7 color index to the matching
+// combination of Win32 FOREGROUND_* bits (the BACKGROUND_* bits are
+// the same values shifted left by 4, see setBg).
+func ansiColorAttr(i int) uint16 {
+	var attr uint16
+	if i&1 != 0 {
+		attr |= foregroundRed
+	}
+	if i&2 != 0 {
+		attr |= foregroundGreen
+	}
+	if i&4 != 0 {
+		attr |= foregroundBlue
+	}
+	return attr
+}
+
+func setFg(attr, fg uint16) uint16 {
+	const mask = foregroundRed | foregroundGreen | foregroundBlue | foregroundIntensity
+	return attr&^mask | fg
+}
+
+func setBg(attr, fg uint16) uint16 {
+	const fgMask = foregroundRed | foregroundGreen | foregroundBlue
+	const bgMask = backgroundRed | backgroundGreen | backgroundBlue | backgroundIntensity
+	bg := (fg & fgMask) << 4
+	if fg&foregroundIntensity != 0 {
+		bg |= backgroundIntensity
+	}
+	return attr&^bgMask | bg
+}
+
+func swapFgBg(attr uint16) uint16 {
+	fg := attr & 0x0f
+	bg := (attr & 0xf0) >> 4
+	return attr&0xff00 | bg | fg<<4
+}
+
+// nearestBasicColor approximates an xterm 256-color index down to 0-7.
+func nearestBasicColor(term256 int) int {
+	return ansi16Index(uint8(term256)) & 7
+}
+
+func nearestBasicColorRGB(r, g, b int) int {
+	idx := 0
+	if r > 127 {
+		idx |= 1
+	}
+	if g > 127 {
+		idx |= 2
+	}
+	if b > 127 {
+		idx |= 4
+	}
+	return idx
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
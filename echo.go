@@ -0,0 +1,17 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+// DisableEcho turns off terminal echo of stdin, so that input (e.g.
+// Ctrl+C) doesn't visibly interfere with the canvas.
+func DisableEcho() {
+	setEcho(false)
+}
+
+// EnableEcho restores terminal echo of stdin.
+func EnableEcho() {
+	setEcho(true)
+}
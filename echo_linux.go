@@ -0,0 +1,30 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build linux
+
+package canvas
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// setEcho flips the ECHO line-discipline flag on stdin via a termios
+// ioctl, replacing the old `stty [-]echo` shell-out.
+func setEcho(enabled bool) {
+	fd := int(os.Stdin.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return
+	}
+	if enabled {
+		t.Lflag |= unix.ECHO
+	} else {
+		t.Lflag &^= unix.ECHO
+	}
+	unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build windows
+
+package canvas
+
+import "golang.org/x/sys/windows"
+
+// setEcho flips ENABLE_ECHO_INPUT on the console input buffer,
+// replacing the old `stty [-]echo` shell-out, which doesn't exist on
+// Windows.
+func setEcho(enabled bool) {
+	handle := windows.Stdin
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return
+	}
+	if enabled {
+		mode |= windows.ENABLE_ECHO_INPUT
+	} else {
+		mode &^= windows.ENABLE_ECHO_INPUT
+	}
+	windows.SetConsoleMode(handle, mode)
+}
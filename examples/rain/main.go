@@ -0,0 +1,132 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/codeliveroil/canvas"
+)
+
+const (
+	width  = 40
+	height = 15
+	drops  = 4
+	fps    = 30
+
+	splashDuration = 80 * time.Millisecond
+)
+
+// dropState walks a raindrop through falling, its two-frame splash,
+// and back to done so main can respawn it.
+type dropState int
+
+const (
+	falling dropState = iota
+	splash1
+	splash2
+	done
+)
+
+// drop is a single raindrop, rendered as a layer so moving or
+// re-skinning it is just AddLayer/MoveLayer/RemoveLayer — Flush's
+// damage tracking takes care of erasing its old position.
+type drop struct {
+	x     int
+	y     float64
+	speed float64 // rows per second
+	clr   canvas.Color
+	layer int
+	state dropState
+	since time.Time
+}
+
+func newDrop(c *canvas.Canvas) *drop {
+	d := &drop{
+		x:     2 + rand.Intn(width-3),
+		speed: 15 + rand.Float64()*20,
+		clr:   canvas.ColorRandom(),
+		since: time.Now(),
+	}
+	sprite := canvas.NewSprite(1, 1)
+	sprite.Set(0, 0, '•', d.clr, canvas.ColorDefault, canvas.StyleNormal)
+	d.layer = c.AddLayer(sprite, d.x, 0, 0)
+	return d
+}
+
+func splashSprite(clr canvas.Color, text string) *canvas.Sprite {
+	return canvas.SpriteFromString(text, ' ', clr, canvas.ColorDefault, canvas.StyleNormal)
+}
+
+// update advances d by dt and reports whether it's still alive.
+func (d *drop) update(c *canvas.Canvas, dt time.Duration) bool {
+	switch d.state {
+	case falling:
+		d.y += d.speed * dt.Seconds()
+		if int(d.y) >= height-1 {
+			c.RemoveLayer(d.layer)
+			d.layer = c.AddLayer(splashSprite(d.clr, ". ."), d.x-1, height-3, 0)
+			d.state, d.since = splash1, time.Now()
+		} else {
+			c.MoveLayer(d.layer, d.x, int(d.y))
+		}
+	case splash1:
+		if time.Since(d.since) >= splashDuration {
+			c.RemoveLayer(d.layer)
+			d.layer = c.AddLayer(splashSprite(d.clr, ".   ."), d.x-2, height-2, 0)
+			d.state, d.since = splash2, time.Now()
+		}
+	case splash2:
+		if time.Since(d.since) >= splashDuration {
+			c.RemoveLayer(d.layer)
+			d.state = done
+		}
+	}
+	return d.state != done
+}
+
+func main() {
+	f, err := os.OpenFile("log.txt", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	logger := log.New(f, "", log.LstdFlags)
+	logger.Println("Starting...")
+
+	// Set up canvas
+	c := canvas.NewCanvas(width, height, canvas.ColorDefault)
+	c.Logger = logger
+	defer c.Close()
+	canvas.HideCursor()
+	defer canvas.ShowCursor()
+	canvas.DisableEcho()
+	defer canvas.EnableEcho()
+	c.Move(10, 14)
+	c.SetForeground(canvas.ColorWhite)
+	c.Write("Press Ctrl+C to quit")
+	c.Flush()
+
+	active := make([]*drop, drops)
+	for i := range active {
+		active[i] = newDrop(c)
+	}
+
+	// Animate drives the frame loop, diffed Flush and Ctrl+C handling;
+	// each drop just moves its layer.
+	c.Animate(fps, func(frame int, dt time.Duration) bool {
+		for i, d := range active {
+			if !d.update(c, dt) {
+				active[i] = newDrop(c)
+			}
+		}
+		return true
+	})
+
+	// Clean up
+	c.SetBackground(canvas.ColorDefault)
+	c.Clear()
+	c.Move(0, 0) // resets the logical write cursor only; the terminal cursor stays where Flush leaves it
+	c.Flush()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/codeliveroil/canvas"
+)
+
+const (
+	width     = 60
+	height    = 13
+	amplitude = 5
+	fps       = 30
+)
+
+// wave renders one sine wave as a layer, one cell per column. Moving
+// the wave forward just updates the sprite in place (clearing the
+// previous cell in each column, setting the new one); Flush's damage
+// tracking takes care of erasing what changed on the terminal.
+type wave struct {
+	sprite    *canvas.Sprite
+	cycles    int
+	direction int
+	clr       canvas.Color
+
+	period  time.Duration // real time between 0.5-step advances, from the original per-wave fps
+	elapsed time.Duration
+	frame   float64
+	prevY   []int
+}
+
+func newWave(c *canvas.Canvas, cycles, direction, sourceFPS int, clr canvas.Color) *wave {
+	w := &wave{
+		sprite:    canvas.NewSprite(width, height),
+		cycles:    cycles,
+		direction: direction,
+		clr:       clr,
+		period:    time.Second / time.Duration(sourceFPS),
+		frame:     0.5,
+		prevY:     make([]int, width),
+	}
+	for x := range w.prevY {
+		w.prevY[x] = -1
+	}
+	c.AddLayer(w.sprite, 0, 0, 0)
+	return w
+}
+
+func (w *wave) update(dt time.Duration) {
+	w.elapsed += dt
+	if w.elapsed < w.period {
+		return
+	}
+	w.elapsed -= w.period
+	w.frame += 0.5
+
+	for x := 0; x < width; x++ {
+		y := int(amplitude*math.Sin(float64(x*2*w.cycles)*(math.Pi/float64(width))+w.frame*float64(w.direction))) + amplitude
+		if y == w.prevY[x] {
+			continue
+		}
+		if w.prevY[x] >= 0 {
+			w.sprite.Clear(x, w.prevY[x])
+		}
+		w.sprite.Set(x, y, '•', w.clr, canvas.ColorDefault, canvas.StyleNormal)
+		w.prevY[x] = y
+	}
+}
+
+func main() {
+	c := canvas.NewCanvas(width, height, canvas.ColorBlack)
+	defer c.Close()
+	canvas.HideCursor()
+	defer canvas.ShowCursor()
+	canvas.DisableEcho()
+	defer canvas.EnableEcho()
+
+	c.Move(20, 12)
+	c.SetForeground(canvas.ColorWhite)
+	c.Write("Press Ctrl+C to quit")
+
+	waves := []*wave{
+		newWave(c, 1, +1, 20, canvas.ColorYellow),
+		newWave(c, 3, -1, 15, canvas.ColorRed),
+	}
+
+	// Animate drives the frame loop, diffed Flush and Ctrl+C handling;
+	// each wave just repaints its layer in place.
+	c.Animate(fps, func(frame int, dt time.Duration) bool {
+		for _, w := range waves {
+			w.update(dt)
+		}
+		return true
+	})
+
+	c.SetBackground(canvas.ColorDefault)
+	c.Clear()
+	c.Move(0, 0) // resets the logical write cursor only; the terminal cursor stays where Flush leaves it
+	c.Flush()
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// escapeTimeout is how long the parser waits after seeing a bare ESC
+// before deciding it really is the Escape key rather than the start of
+// a CSI sequence. xterm and friends emit the whole sequence for a key
+// press in well under this window.
+const escapeTimeout = 100 * time.Millisecond
+
+// EventType identifies which field of an Event is populated.
+type EventType int
+
+const (
+	EventKey EventType = iota
+	EventMouse
+	EventResize
+	EventPaste
+)
+
+// Key identifies a non-printable or control key.
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyEnter
+	KeyEscape
+	KeyTab
+	KeyBackspace
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyDelete
+	KeyInsert
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+// Mods is a bitmask of modifier keys held during a KeyEvent or
+// MouseEvent.
+type Mods int
+
+const (
+	ModShift Mods = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// MouseButton identifies which button a MouseEvent refers to.
+type MouseButton int
+
+const (
+	MouseNone MouseButton = iota
+	MouseLeft
+	MouseMiddle
+	MouseRight
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// MouseAction identifies what happened to a MouseButton.
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseDrag
+)
+
+// KeyEvent describes a single key press. Rune is set (and Key is
+// KeyNone) for printable characters; Key is set for control and
+// function keys.
+type KeyEvent struct {
+	Key  Key
+	Rune rune
+	Mods Mods
+}
+
+// MouseEvent describes a mouse button or wheel action. X and Y are the
+// 0-indexed column and row within the terminal window, as the terminal
+// reports them; they are not offset by the canvas' position.
+type MouseEvent struct {
+	X, Y   int
+	Button MouseButton
+	Action MouseAction
+	Mods   Mods
+}
+
+// ResizeEvent is delivered when the terminal window is resized
+// (SIGWINCH).
+type ResizeEvent struct {
+	W, H int
+}
+
+// PasteEvent carries the literal text of a bracketed paste.
+type PasteEvent struct {
+	Text string
+}
+
+// Event is a sum type: exactly one of Key, Mouse, Resize or Paste is
+// populated, as indicated by Type.
+type Event struct {
+	Type   EventType
+	Key    KeyEvent
+	Mouse  MouseEvent
+	Resize ResizeEvent
+	Paste  PasteEvent
+}
+
+// Input reads raw bytes from stdin, decodes them into Events, and
+// reports terminal resizes. Create one with (*Canvas).Input and stop
+// it with Close to restore the terminal.
+type Input struct {
+	events  chan Event
+	done    chan struct{}
+	oldTerm *term.State
+}
+
+// Input puts the terminal into raw mode, enables xterm mouse tracking
+// and bracketed paste, and starts decoding stdin into Events. Call
+// Close when done to restore the terminal to its prior state.
+func (c *Canvas) Input() (*Input, error) {
+	oldTerm, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(stdout, escape+"[?1000h"+escape+"[?1006h"+escape+"[?2004h")
+
+	in := &Input{
+		events:  make(chan Event, 64),
+		done:    make(chan struct{}),
+		oldTerm: oldTerm,
+	}
+	go in.readLoop()
+	go in.watchResize()
+	return in, nil
+}
+
+// Events returns the channel that Events are delivered on. Close stops
+// delivery but does not close the channel.
+func (in *Input) Events() <-chan Event {
+	return in.events
+}
+
+// Close restores the terminal to the state it was in before Input was
+// called and disables mouse tracking and bracketed paste.
+func (in *Input) Close() error {
+	close(in.done)
+	fmt.Fprint(stdout, escape+"[?1000l"+escape+"[?1006l"+escape+"[?2004l")
+	return term.Restore(int(os.Stdin.Fd()), in.oldTerm)
+}
+
+// watchResize reports terminal resizes as ResizeEvents. How a resize
+// is actually detected is platform-specific (resizeNotifier, in
+// resize_unix.go / resize_windows.go): SIGWINCH doesn't exist outside
+// Unix.
+func (in *Input) watchResize() {
+	notify, stop := resizeNotifier()
+	defer stop()
+	for {
+		select {
+		case <-in.done:
+			return
+		case <-notify:
+			if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				in.emit(Event{Type: EventResize, Resize: ResizeEvent{W: w, H: h}})
+			}
+		}
+	}
+}
+
+// readLoop decodes stdin into Events. A lone ESC byte is ambiguous: it
+// might be the Escape key, or the first byte of a CSI sequence that
+// just hasn't arrived yet. reads() feeds bytes over a channel so that
+// this loop can hold a lone ESC for escapeTimeout without blocking
+// further reads, and resolve it to the Escape key if nothing follows.
+func (in *Input) readLoop() {
+	chunks := in.reads()
+	var pending []byte
+	var escTimer *time.Timer
+	defer stopTimer(escTimer)
+
+	for {
+		var timeout <-chan time.Time
+		if escTimer != nil {
+			timeout = escTimer.C
+		}
+		select {
+		case <-in.done:
+			return
+		case chunk, ok := <-chunks:
+			if !ok {
+				return
+			}
+			pending = append(pending, chunk...)
+		case <-timeout:
+			in.emit(Event{Type: EventKey, Key: KeyEvent{Key: KeyEscape}})
+			pending = pending[1:]
+			escTimer = nil
+		}
+
+		for len(pending) > 0 {
+			consumed := in.decode(pending)
+			if consumed == 0 {
+				if pending[0] == 0x1b && len(pending) == 1 && escTimer == nil {
+					escTimer = time.NewTimer(escapeTimeout)
+				}
+				break
+			}
+			stopTimer(escTimer)
+			escTimer = nil
+			pending = pending[consumed:]
+		}
+	}
+}
+
+// reads starts a goroutine that reads raw bytes from stdin and
+// delivers them on the returned channel, which is closed when Close
+// has been called or the read fails.
+func (in *Input) reads() <-chan []byte {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			select {
+			case out <- chunk:
+			case <-in.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// decode consumes one key, escape sequence or paste from the front of
+// b, emitting its Event if it decodes to one (unrecognised sequences
+// are consumed without an Event), and returns how many bytes were
+// consumed, or 0 if b is an incomplete prefix of a longer sequence.
+func (in *Input) decode(b []byte) int {
+	if b[0] != 0x1b {
+		return in.decodeRune(b)
+	}
+	if len(b) == 1 {
+		return 0 // wait for escapeTimeout or the rest of a CSI sequence
+	}
+	if b[1] == '[' {
+		return in.decodeCSI(b)
+	}
+	return 1 // lone ESC followed by an unrelated byte; drop the ESC
+}
+
+func (in *Input) decodeRune(b []byte) int {
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError && size <= 1 {
+		r, size = rune(b[0]), 1
+	}
+	if r == 0x7f || r == 0x08 {
+		in.emit(Event{Type: EventKey, Key: KeyEvent{Key: KeyBackspace}})
+		return size
+	}
+	if r == '\r' || r == '\n' {
+		in.emit(Event{Type: EventKey, Key: KeyEvent{Key: KeyEnter}})
+		return size
+	}
+	if r == '\t' {
+		in.emit(Event{Type: EventKey, Key: KeyEvent{Key: KeyTab}})
+		return size
+	}
+	if r < 0x20 { // Ctrl+letter: Ctrl+A is 0x01, Ctrl+Z is 0x1a
+		in.emit(Event{Type: EventKey, Key: KeyEvent{Rune: rune('a' + r - 1), Mods: ModCtrl}})
+		return size
+	}
+	in.emit(Event{Type: EventKey, Key: KeyEvent{Rune: r}})
+	return size
+}
+
+var csiKeys = map[byte]Key{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+}
+
+var csiTildeKeys = map[int]Key{
+	1:  KeyHome,
+	2:  KeyInsert,
+	3:  KeyDelete,
+	4:  KeyEnd,
+	5:  KeyPageUp,
+	6:  KeyPageDown,
+	11: KeyF1,
+	12: KeyF2,
+	13: KeyF3,
+	14: KeyF4,
+	15: KeyF5,
+	17: KeyF6,
+	18: KeyF7,
+	19: KeyF8,
+	20: KeyF9,
+	21: KeyF10,
+	23: KeyF11,
+	24: KeyF12,
+}
+
+// decodeCSI parses a "\e[..." sequence: arrow/Home/End keys
+// (\e[A..\e[F), function/navigation keys with a numeric parameter
+// (\e[3~), SGR mouse reports (\e[<b;x;yM or m), and bracketed paste
+// (\e[200~ ... \e[201~).
+func (in *Input) decodeCSI(b []byte) int {
+	if len(b) >= 3 && b[2] == '<' {
+		return in.decodeSGRMouse(b)
+	}
+	i := 2
+	for i < len(b) && (b[i] == ';' || (b[i] >= '0' && b[i] <= '9')) {
+		i++
+	}
+	if i >= len(b) {
+		return 0 // final byte hasn't arrived yet
+	}
+	final := b[i]
+	params := string(b[2:i])
+	switch final {
+	case 'A', 'B', 'C', 'D', 'H', 'F':
+		in.emit(Event{Type: EventKey, Key: KeyEvent{Key: csiKeys[final]}})
+		return i + 1
+	case '~':
+		n, _ := strconv.Atoi(params)
+		if n == 200 {
+			return in.decodeBracketedPaste(b)
+		}
+		if key, ok := csiTildeKeys[n]; ok {
+			in.emit(Event{Type: EventKey, Key: KeyEvent{Key: key}})
+		}
+		return i + 1
+	}
+	return i + 1
+}
+
+// decodeBracketedPaste consumes "\e[200~ <text> \e[201~" and emits the
+// text between the markers as a single PasteEvent. It returns 0 (wait
+// for more input) if the closing marker hasn't arrived yet.
+func (in *Input) decodeBracketedPaste(b []byte) int {
+	const end = "\x1b[201~"
+	start := len("\x1b[200~")
+	idx := bytes.Index(b[start:], []byte(end))
+	if idx < 0 {
+		return 0
+	}
+	in.emit(Event{Type: EventPaste, Paste: PasteEvent{Text: string(b[start : start+idx])}})
+	return start + idx + len(end)
+}
+
+// decodeSGRMouse parses "\e[<b;x;yM" (press/drag) or "...m" (release).
+func (in *Input) decodeSGRMouse(b []byte) int {
+	i := 3
+	for i < len(b) && b[i] != 'M' && b[i] != 'm' {
+		i++
+	}
+	if i >= len(b) {
+		return 0
+	}
+	final := b[i]
+	parts := strings.SplitN(string(b[3:i]), ";", 3)
+	if len(parts) != 3 {
+		return i + 1
+	}
+	code, _ := strconv.Atoi(parts[0])
+	px, _ := strconv.Atoi(parts[1])
+	py, _ := strconv.Atoi(parts[2])
+	x, y := px-1, py-1
+
+	ev := MouseEvent{X: x, Y: y}
+	if code&4 != 0 {
+		ev.Mods |= ModShift
+	}
+	if code&8 != 0 {
+		ev.Mods |= ModAlt
+	}
+	if code&16 != 0 {
+		ev.Mods |= ModCtrl
+	}
+	switch {
+	case code&32 != 0:
+		ev.Action = MouseDrag
+	case final == 'm':
+		ev.Action = MouseRelease
+	default:
+		ev.Action = MousePress
+	}
+	switch code & 0x43 {
+	case 0:
+		ev.Button = MouseLeft
+	case 1:
+		ev.Button = MouseMiddle
+	case 2:
+		ev.Button = MouseRight
+	case 0x40:
+		ev.Button = MouseWheelUp
+	case 0x41:
+		ev.Button = MouseWheelDown
+	}
+	in.emit(Event{Type: EventMouse, Mouse: ev})
+	return i + 1
+}
+
+func (in *Input) emit(ev Event) {
+	select {
+	case in.events <- ev:
+	case <-in.done:
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+import (
+	"io"
+	"os"
+)
+
+// stdout is where Canvas writes its escape-coded output. It's
+// ordinarily os.Stdout; on a Windows console that doesn't support the
+// virtual-terminal mode, enableVT swaps it for a writer that
+// translates the ANSI this package emits into Win32 console calls
+// instead.
+var stdout io.Writer = os.Stdout
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build !windows
+
+package canvas
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// resizeNotifier returns a channel that fires whenever the terminal
+// reports a resize, and a func that releases the underlying signal
+// registration. Unix terminals raise SIGWINCH on resize.
+func resizeNotifier() (<-chan struct{}, func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+
+	notify := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+				select {
+				case notify <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return notify, func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
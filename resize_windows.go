@@ -0,0 +1,52 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+//go:build windows
+
+package canvas
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// resizePollInterval is how often resizeNotifier checks the console
+// buffer size on Windows, which has no SIGWINCH equivalent to push
+// resize notifications.
+const resizePollInterval = 250 * time.Millisecond
+
+// resizeNotifier returns a channel that fires whenever the console
+// buffer size changes, and a func that stops the poller. Windows
+// consoles don't raise a resize signal, so this polls term.GetSize
+// instead of reacting to one.
+func resizeNotifier() (<-chan struct{}, func()) {
+	notify := make(chan struct{}, 1)
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+
+		w, h, _ := term.GetSize(int(os.Stdout.Fd()))
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if nw, nh, err := term.GetSize(int(os.Stdout.Fd())); err == nil && (nw != w || nh != h) {
+					w, h = nw, nh
+					select {
+					case notify <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return notify, func() { close(done) }
+}
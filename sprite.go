@@ -0,0 +1,232 @@
+// Copyright (c) 2018 codeliveroil. All rights reserved.
+//
+// This work is licensed under the terms of the MIT license.
+// For a copy, see <https://opensource.org/licenses/MIT>.
+
+package canvas
+
+import (
+	"image"
+	"sort"
+	"strings"
+)
+
+// halfBlock is the upper half block glyph used by SpriteFromImage to
+// pack two vertical pixels into one terminal cell: the foreground
+// color paints the top pixel, the background color paints the bottom.
+const halfBlock = '▀'
+
+// Sprite is a small rectangular grid of cells that can be composited
+// onto a Canvas via AddLayer. Cells marked transparent let whatever is
+// beneath them (a lower layer, or the canvas' own background) show
+// through.
+type Sprite struct {
+	Width  int
+	Height int
+
+	cells [][]spriteCell
+}
+
+type spriteCell struct {
+	r           rune
+	fg          Color
+	bg          Color
+	style       int
+	transparent bool
+}
+
+// NewSprite returns a width x height Sprite with every cell
+// transparent.
+func NewSprite(width, height int) *Sprite {
+	cells := make([][]spriteCell, height)
+	for y := range cells {
+		row := make([]spriteCell, width)
+		for x := range row {
+			row[x] = spriteCell{transparent: true}
+		}
+		cells[y] = row
+	}
+	return &Sprite{Width: width, Height: height, cells: cells}
+}
+
+// Set paints a single cell of the sprite. Out-of-bounds coordinates
+// are silently ignored.
+func (s *Sprite) Set(x, y int, r rune, fg, bg Color, style int) {
+	if x < 0 || x >= s.Width || y < 0 || y >= s.Height {
+		return
+	}
+	s.cells[y][x] = spriteCell{r: r, fg: fg, bg: bg, style: style}
+}
+
+// Clear marks a single cell of the sprite as transparent again.
+func (s *Sprite) Clear(x, y int) {
+	if x < 0 || x >= s.Width || y < 0 || y >= s.Height {
+		return
+	}
+	s.cells[y][x] = spriteCell{transparent: true}
+}
+
+// SpriteFromString builds a Sprite from a multi-line string, one rune
+// per cell. transparent is a color-key rune (e.g. '.') whose cells are
+// left transparent; every other rune is painted with fg, bg and
+// style. The sprite's width is the length of its longest line.
+func SpriteFromString(art string, transparent rune, fg, bg Color, style int) *Sprite {
+	lines := strings.Split(art, "\n")
+	width := 0
+	runes := make([][]rune, len(lines))
+	for i, line := range lines {
+		runes[i] = []rune(line)
+		if len(runes[i]) > width {
+			width = len(runes[i])
+		}
+	}
+	s := NewSprite(width, len(lines))
+	for y, line := range runes {
+		for x, r := range line {
+			if r == transparent {
+				continue
+			}
+			s.Set(x, y, r, fg, bg, style)
+		}
+	}
+	return s
+}
+
+// SpriteFromImage decodes img into a Sprite using the half-block
+// technique: each cell packs two vertical source pixels, the top
+// rendered as the cell's foreground color and the bottom as its
+// background color, against the U+2580 UPPER HALF BLOCK glyph. This
+// roughly doubles the vertical resolution an ordinary character cell
+// can display.
+func SpriteFromImage(img image.Image) *Sprite {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	height := (h + 1) / 2
+	s := NewSprite(w, height)
+	for cy := 0; cy < height; cy++ {
+		topY := bounds.Min.Y + cy*2
+		botY := topY + 1
+		for x := 0; x < w; x++ {
+			top := img.At(bounds.Min.X+x, topY)
+			bot := top
+			if botY < bounds.Max.Y {
+				bot = img.At(bounds.Min.X+x, botY)
+			}
+			s.Set(x, cy, halfBlock, Color{RGBA: top}, Color{RGBA: bot}, StyleNormal)
+		}
+	}
+	return s
+}
+
+// layer pins a Sprite at a position and z-order on a Canvas.
+type layer struct {
+	id     int
+	sprite *Sprite
+	x, y   int
+	z      int
+}
+
+// AddLayer adds sprite to the canvas at (x,y), stacked at z-order z
+// (higher paints over lower), and returns an id that MoveLayer and
+// RemoveLayer use to refer to it. Layers are composited over the back
+// buffer every Flush; they don't modify it.
+func (c *Canvas) AddLayer(sprite *Sprite, x, y, z int) int {
+	if c.layers == nil {
+		c.layers = make(map[int]*layer)
+	}
+	c.nextLayerID++
+	id := c.nextLayerID
+	c.layers[id] = &layer{id: id, sprite: sprite, x: x, y: y, z: z}
+	return id
+}
+
+func (c *Canvas) AddLayerSafe(sprite *Sprite, x, y, z int) int {
+	c.mutex.Lock()
+	id := c.AddLayer(sprite, x, y, z)
+	c.mutex.Unlock()
+	return id
+}
+
+// MoveLayer repositions the layer identified by id. It's a no-op if id
+// doesn't refer to a live layer.
+func (c *Canvas) MoveLayer(id, x, y int) {
+	if l, ok := c.layers[id]; ok {
+		l.x, l.y = x, y
+	}
+}
+
+func (c *Canvas) MoveLayerSafe(id, x, y int) {
+	c.mutex.Lock()
+	c.MoveLayer(id, x, y)
+	c.mutex.Unlock()
+}
+
+// RemoveLayer removes the layer identified by id. It's a no-op if id
+// doesn't refer to a live layer.
+func (c *Canvas) RemoveLayer(id int) {
+	delete(c.layers, id)
+}
+
+func (c *Canvas) RemoveLayerSafe(id int) {
+	c.mutex.Lock()
+	c.RemoveLayer(id)
+	c.mutex.Unlock()
+}
+
+// composeFrame returns the grid that Flush should render: the back
+// buffer as-is if there are no layers, or a copy of it with every
+// layer painted on top back-to-front by z-order otherwise.
+func (c *Canvas) composeFrame() [][]cell {
+	if len(c.layers) == 0 {
+		return c.back
+	}
+	frame := make([][]cell, c.Height)
+	for y := range frame {
+		row := make([]cell, c.Width)
+		copy(row, c.back[y])
+		frame[y] = row
+	}
+	for _, l := range c.sortedLayers() {
+		l.paint(frame, c.Width, c.Height)
+	}
+	return frame
+}
+
+func (c *Canvas) sortedLayers() []*layer {
+	layers := make([]*layer, 0, len(c.layers))
+	for _, l := range c.layers {
+		layers = append(layers, l)
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		if layers[i].z != layers[j].z {
+			return layers[i].z < layers[j].z
+		}
+		// c.layers is a map, so iteration order (and thus
+		// sort.Slice's tie-breaking) isn't deterministic on its own;
+		// break ties on id, which increases with insertion order, so
+		// two layers sharing a z always composite in the order they
+		// were added.
+		return layers[i].id < layers[j].id
+	})
+	return layers
+}
+
+func (l *layer) paint(frame [][]cell, width, height int) {
+	for sy := 0; sy < l.sprite.Height; sy++ {
+		ty := l.y + sy
+		if ty < 0 || ty >= height {
+			continue
+		}
+		for sx := 0; sx < l.sprite.Width; sx++ {
+			tx := l.x + sx
+			if tx < 0 || tx >= width {
+				continue
+			}
+			sc := l.sprite.cells[sy][sx]
+			if sc.transparent {
+				continue
+			}
+			frame[ty][tx] = cell{r: sc.r, fg: sc.fg, bg: sc.bg, style: sc.style}
+		}
+	}
+}